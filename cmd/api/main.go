@@ -1,76 +1,368 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"log"
-	"net"
 	"net/http"
-	"strings"
+	"net/http/httputil"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/config"
+	"github.com/leo-jp-edwards/goad-balancer/internal/health"
+	"github.com/leo-jp-edwards/goad-balancer/internal/hostmatch"
+	"github.com/leo-jp-edwards/goad-balancer/internal/middleware"
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		checkConfig(os.Args[2:])
+		return
+	}
+
+	configPath := flag.String("config", "", "path to config file (YAML); if unset, a built-in demo route table is used")
+	tlsStrictSNI := flag.Bool("tls-strict-sni", false, "reject TLS handshakes whose SNI matches no configured host with \"tls: unrecognized name\"")
+	flag.Parse()
+
+	tableFunc, mwCfg, tlsCfg, timeouts, err := tableSource(*configPath)
+	if err != nil {
+		log.Fatalf("loading config: %v", err)
+	}
+
+	mws, err := buildMiddleware(mwCfg)
+	if err != nil {
+		log.Fatalf("configuring middleware: %v", err)
+	}
+
+	handler := middleware.Chain(newHandler(tableFunc), mws...)
+
+	if tlsCfg != nil {
+		tlsCfg.StrictSNI = tlsCfg.StrictSNI || *tlsStrictSNI
+		if err := serveTLS(tlsCfg, timeouts, handler); err != nil {
+			log.Fatalf("configuring tls: %v", err)
+		}
+		return
+	}
+
 	srv := &http.Server{
 		Addr:              ":8080",
-		Handler:           newHandler(),
-		ReadHeaderTimeout: 5 * time.Second,
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeader,
 	}
 
 	log.Printf("listening on http://localhost:8080")
 	log.Fatal(srv.ListenAndServe())
 }
 
-func newHandler() http.Handler {
-	hostRoutes := map[string]string{
-		"mango.com": "site-mango",
-		"apple.com": "site-apple",
+// serveTLS starts the :443 listener with SNI-based certificate selection
+// (and, if cfg.RedirectHTTP is set, a :80 listener that redirects to
+// https://). It blocks, like http.Server.ListenAndServe.
+func serveTLS(cfg *config.TLSConfig, timeouts config.TimeoutsConfig, handler http.Handler) error {
+	manager, err := config.BuildTLSManager(cfg)
+	if err != nil {
+		return err
 	}
 
+	httpsSrv := &http.Server{
+		Addr:              ":443",
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		TLSConfig:         &tls.Config{GetCertificate: manager.GetCertificate},
+	}
+
+	if cfg.RedirectHTTP {
+		redirect := manager.ACMEHandler(http.HandlerFunc(redirectToHTTPS))
+		httpSrv := &http.Server{
+			Addr:              ":80",
+			Handler:           redirect,
+			ReadHeaderTimeout: timeouts.ReadHeader,
+		}
+		go func() {
+			log.Printf("listening on http://localhost:80 (redirecting to https)")
+			log.Fatal(httpSrv.ListenAndServe())
+		}()
+	}
+
+	log.Printf("listening on https://localhost:443")
+	return httpsSrv.ListenAndServeTLS("", "")
+}
+
+// redirectToHTTPS sends every request to its https:// equivalent.
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	target := "https://" + hostmatch.Canonical(r.Host) + r.URL.RequestURI()
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
+
+// buildMiddleware turns the config's middleware toggles into an ordered
+// stack. ProxyHeaders runs first so hostmatch.Canonical always sees the real
+// client host; Recovery wraps everything else so a panic anywhere still
+// gets logged and answered with a 500.
+func buildMiddleware(cfg config.MiddlewareConfig) ([]middleware.Middleware, error) {
+	var mws []middleware.Middleware
+
+	if cfg.ProxyHeaders != nil {
+		mw, err := middleware.ProxyHeaders(cfg.ProxyHeaders.TrustedCIDRs)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, mw)
+	}
+
+	if cfg.Recovery {
+		mws = append(mws, middleware.Recovery(log.Default()))
+	}
+
+	if cfg.AccessLog != nil {
+		w, err := accessLogWriter(*cfg.AccessLog)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, middleware.AccessLog(w))
+	}
+
+	if cfg.CORS != nil {
+		mws = append(mws, middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+		}))
+	}
+
+	if cfg.Gzip != nil {
+		mws = append(mws, middleware.Gzip(middleware.GzipConfig{
+			ContentTypes: cfg.Gzip.ContentTypes,
+			MinSize:      cfg.Gzip.MinSize,
+		}))
+	}
+
+	return mws, nil
+}
+
+// accessLogWriter returns os.Stdout when no path is configured, or a
+// lumberjack-rotated file writer otherwise.
+func accessLogWriter(cfg config.AccessLogConfig) (io.Writer, error) {
+	if cfg.Path == "" {
+		return os.Stdout, nil
+	}
+
+	return &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAgeDays,
+	}, nil
+}
+
+// checkConfig implements `goadbalancer check-config path`: it validates the
+// config without starting the server.
+func checkConfig(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: goadbalancer check-config path")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	if _, err := cfg.BuildRouteTable(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: ok\n", args[0])
+}
+
+// tableSource returns a getter for the current RouteTable (starting the
+// active health-check supervisor on it) along with the middleware and TLS
+// config to serve it with. With a config path it loads the file and keeps
+// the route table fresh via config.Watcher; with no path it falls back to
+// the built-in demo table and an empty (all opt-in middleware and TLS
+// disabled) config.
+//
+// The TLS config is a snapshot of whatever was loaded at startup: unlike
+// the route table, it isn't kept fresh across reloads, since rotating a
+// listener's certificates live is out of scope for hot-reload here.
+func tableSource(configPath string) (func() *config.RouteTable, config.MiddlewareConfig, *config.TLSConfig, config.TimeoutsConfig, error) {
+	supervisor := health.NewSupervisor()
+
+	if configPath == "" {
+		log.Printf("no --config given, using built-in demo route table")
+		table := buildDemoTable()
+		supervisor.Retarget(table.Pools, table.Checks)
+		return func() *config.RouteTable { return table }, config.MiddlewareConfig{}, nil, table.Timeouts, nil
+	}
+
+	watcher, err := config.NewWatcher(configPath)
+	if err != nil {
+		return nil, config.MiddlewareConfig{}, nil, config.TimeoutsConfig{}, err
+	}
+	supervisor.Retarget(watcher.Table().Pools, watcher.Table().Checks)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer stop()
+		if err := watcher.Watch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("config watcher stopped: %v", err)
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-watcher.Reloaded():
+				table := watcher.Table()
+				supervisor.Retarget(table.Pools, table.Checks)
+			}
+		}
+	}()
+
+	return watcher.Table, watcher.Config().Middleware, watcher.Config().TLS, watcher.Table().Timeouts, nil
+}
+
+func newHandler(table func() *config.RouteTable) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		status, pools := healthSnapshot(table())
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 
 		_ = json.NewEncoder(w).Encode(healthResponse{
-			Status: "ok",
+			Status: status,
 			Time:   time.Now().Format(time.RFC3339Nano),
+			Pools:  pools,
 		})
 	})
 
-	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
-		host := canonicalHost(r.Host)
-
-		route, ok := hostRoutes[host]
-		if !ok {
-			http.NotFound(w, r)
-			return
+	mux.HandleFunc("GET /admin/pools", func(w http.ResponseWriter, r *http.Request) {
+		current := table().Pools
+		dump := make(map[string][]pool.State, len(current))
+		for host, p := range current {
+			dump[host] = p.Snapshot()
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-
-		_ = json.NewEncoder(w).Encode(hostResponse{
-			Host:  host,
-			Route: route,
-		})
+		_ = json.NewEncoder(w).Encode(dump)
 	})
 
+	mux.HandleFunc("/", proxyHandler(table))
+
 	return mux
 }
 
-// canonicalHost strips an optional :port and normalises case
-// it also tolerates bracketed IPv6 hosts like "[::1]:8080"
-func canonicalHost(h string) string {
-	h = strings.TrimSpace(strings.ToLower(h))
-	if h == "" {
-		return ""
+// healthSnapshot reports "ok" when every pool is fully healthy, "degraded"
+// when some pool has a down backend but still has capacity, and
+// "unhealthy" when any pool has zero live backends.
+func healthSnapshot(table *config.RouteTable) (status string, pools map[string]string) {
+	status = "ok"
+	pools = make(map[string]string, len(table.Pools))
+
+	for host, p := range table.Pools {
+		s := p.Status()
+		pools[host] = s
+
+		switch {
+		case s == "unhealthy":
+			status = "unhealthy"
+		case s == "degraded" && status == "ok":
+			status = "degraded"
+		}
+	}
+
+	return status, pools
+}
+
+// buildDemoTable is the built-in route table used when the balancer is
+// started without --config.
+func buildDemoTable() *config.RouteTable {
+	return &config.RouteTable{
+		Pools: map[string]*pool.Pool{
+			"mango.com": mustPool("site-mango", "http://127.0.0.1:9001"),
+			"apple.com": mustPool("site-apple", "http://127.0.0.1:9002"),
+		},
+		Passive: map[string]config.PassiveConfig{
+			"mango.com": {MaxFails: 1, FailTimeout: 10 * time.Second},
+			"apple.com": {MaxFails: 1, FailTimeout: 10 * time.Second},
+		},
+		Checks:   map[string]health.ProbeConfig{},
+		Timeouts: config.TimeoutsWithDefaults(config.TimeoutsConfig{}),
+	}
+}
+
+func mustPool(name, upstream string) *pool.Pool {
+	backend, err := pool.NewBackend(upstream, 1)
+	if err != nil {
+		log.Fatalf("invalid upstream %q for pool %q: %v", upstream, name, err)
 	}
-	// if it looks like host:port or [v6]:port, split
-	if host, _, err := net.SplitHostPort(h); err == nil {
-		// net.SplitHostPort keeps brackets off the returned host for IPv6
-		return strings.Trim(host, "[]")
+	return pool.New(name, &pool.RoundRobin{}, backend)
+}
+
+// proxyHandler returns a catch-all handler that picks a backend for the
+// request's host out of the current table and forwards every method and
+// path to it, recording passive health-check failures along the way.
+func proxyHandler(table func() *config.RouteTable) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := hostmatch.Canonical(r.Host)
+		current := table()
+
+		p, ok := current.Pools[host]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if rt, ok := current.Routers[host]; ok {
+			p, r = rt.Match(r)
+		}
+
+		backend := p.Next(r)
+		if backend == nil {
+			http.Error(w, "no healthy upstream", http.StatusBadGateway)
+			return
+		}
+
+		if d := current.Timeouts.Upstream; d > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+
+		passive := current.Passive[host]
+
+		done := backend.StartRequest()
+		defer done()
+
+		proxy := httputil.NewSingleHostReverseProxy(backend.URL)
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			if resp.StatusCode >= http.StatusInternalServerError {
+				backend.RecordFailure(passive.MaxFails, passive.FailTimeout)
+			} else {
+				backend.RecordSuccess()
+			}
+			return nil
+		}
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			backend.RecordFailure(passive.MaxFails, passive.FailTimeout)
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		}
+
+		proxy.ServeHTTP(w, r)
 	}
-	// otherwise it may already be a host
-	return strings.Trim(h, "[]")
 }
@@ -4,11 +4,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/config"
+	"github.com/leo-jp-edwards/goad-balancer/internal/health"
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
 )
 
 func TestHealthEndpoint(t *testing.T) {
-	ts := httptest.NewServer(newHandler())
+	ts := httptest.NewServer(newHandler(testTable(t)))
 	t.Cleanup(ts.Close)
 
 	resp, err := http.Get(ts.URL + "/health")
@@ -25,17 +33,65 @@ func TestHealthEndpoint(t *testing.T) {
 		t.Fatalf("missing Content-Type header")
 	}
 
-	var body struct {
-		Status string `json:"status"`
-		Time   string `json:"time"`
+	var body healthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode response JSON: %v", err)
+	}
+
+	if body.Status != "ok" {
+		t.Fatalf("unexpected status: got %q want %q", body.Status, "ok")
+	}
+}
+
+func TestHealthEndpointReportsDegradedAndUnhealthy(t *testing.T) {
+	mango := fakeUpstream(t, "site-mango")
+	mangoBackend, err := pool.NewBackend(mango.URL, 1)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	downBackend, err := pool.NewBackend("http://127.0.0.1:1", 1)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	downBackend.SetHealthy(false)
+
+	table := &config.RouteTable{
+		Pools: map[string]*pool.Pool{
+			"mango.com": pool.New("site-mango", &pool.RoundRobin{}, mangoBackend, downBackend),
+			"apple.com": pool.New("site-apple", &pool.RoundRobin{}, downBackend),
+		},
+		Passive: map[string]config.PassiveConfig{},
+		Checks:  map[string]health.ProbeConfig{},
 	}
+
+	ts := httptest.NewServer(newHandler(func() *config.RouteTable { return table }))
+	t.Cleanup(ts.Close)
+
+	resp, err := http.Get(ts.URL + "/health")
+	if err != nil {
+		t.Fatalf("GET /health failed: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	var body healthResponse
 	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		t.Fatalf("time field is not RFC3339Nano: %q (%v)", body.Time, err)
+		t.Fatalf("decode response JSON: %v", err)
+	}
+
+	if body.Status != "unhealthy" {
+		t.Fatalf("unexpected overall status: got %q want %q", body.Status, "unhealthy")
+	}
+	if body.Pools["mango.com"] != "degraded" {
+		t.Fatalf("unexpected mango.com status: got %q want %q", body.Pools["mango.com"], "degraded")
+	}
+	if body.Pools["apple.com"] != "unhealthy" {
+		t.Fatalf("unexpected apple.com status: got %q want %q", body.Pools["apple.com"], "unhealthy")
 	}
 }
 
 func TestHostRouting(t *testing.T) {
-	ts := httptest.NewServer(newHandler())
+	table, upstreams := testTableWithUpstreams(t)
+	ts := httptest.NewServer(newHandler(table))
 	t.Cleanup(ts.Close)
 
 	client := ts.Client()
@@ -44,31 +100,30 @@ func TestHostRouting(t *testing.T) {
 		description    string
 		host           string
 		expectedStatus int
-		expectedRoute  string
+		expectedBody   string
 	}{
 		{
 			description:    "mango server",
 			host:           "mango.com",
 			expectedStatus: 200,
-			expectedRoute:  "site-mango",
+			expectedBody:   "site-mango",
 		},
 		{
 			description:    "apple server",
 			host:           "apple.com",
 			expectedStatus: 200,
-			expectedRoute:  "site-apple",
+			expectedBody:   "site-apple",
 		},
 		{
 			description:    "missing server",
 			host:           "notmango.com",
 			expectedStatus: 404,
-			expectedRoute:  "",
 		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.description, func(t *testing.T) {
-			req, err := http.NewRequest(http.MethodGet, ts.URL+"/", nil)
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/anything", nil)
 			if err != nil {
 				t.Fatalf("new request: %v", err)
 			}
@@ -90,20 +145,230 @@ func TestHostRouting(t *testing.T) {
 			}
 
 			var body struct {
-				Host  string `json:"host"`
-				Route string `json:"route"`
+				Backend string `json:"backend"`
 			}
 			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
 				t.Fatalf("decode response JSON: %v", err)
 			}
 
-			if body.Route != tc.expectedRoute {
-				t.Fatalf("unexpected route: got %q want %q", body.Route, tc.expectedRoute)
+			if body.Backend != tc.expectedBody {
+				t.Fatalf("unexpected backend: got %q want %q", body.Backend, tc.expectedBody)
+			}
+		})
+	}
+
+	_ = upstreams // keep servers alive for the duration of the subtests
+}
+
+func TestProxyRecordsPassiveFailures(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(failing.Close)
+
+	backend, err := pool.NewBackend(failing.URL, 1)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+
+	table := &config.RouteTable{
+		Pools: map[string]*pool.Pool{
+			"mango.com": pool.New("site-mango", &pool.RoundRobin{}, backend),
+		},
+		Passive: map[string]config.PassiveConfig{
+			"mango.com": {MaxFails: 2, FailTimeout: time.Minute},
+		},
+		Checks: map[string]health.ProbeConfig{},
+	}
+
+	ts := httptest.NewServer(newHandler(func() *config.RouteTable { return table }))
+	t.Cleanup(ts.Close)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = "mango.com"
+
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("do request: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if backend.Healthy() {
+		t.Fatalf("expected backend to be marked unhealthy after repeated 5xx responses")
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://mango.com:8080/widgets?x=1", nil)
+	req.Host = "mango.com:8080"
+
+	w := httptest.NewRecorder()
+	redirectToHTTPS(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("unexpected status: got %d want %d", w.Code, http.StatusMovedPermanently)
+	}
+
+	want := "https://mango.com/widgets?x=1"
+	if got := w.Header().Get("Location"); got != want {
+		t.Fatalf("unexpected redirect target: got %q want %q", got, want)
+	}
+}
+
+func TestAdminPools(t *testing.T) {
+	ts := httptest.NewServer(newHandler(testTable(t)))
+	t.Cleanup(ts.Close)
+
+	resp, err := http.Get(ts.URL + "/admin/pools")
+	if err != nil {
+		t.Fatalf("GET /admin/pools failed: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: got %d want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var dump map[string][]pool.State
+	if err := json.NewDecoder(resp.Body).Decode(&dump); err != nil {
+		t.Fatalf("decode response JSON: %v", err)
+	}
+
+	if len(dump["mango.com"]) != 1 {
+		t.Fatalf("expected one backend for mango.com, got %d", len(dump["mango.com"]))
+	}
+}
+
+// TestCheckConfig exercises the check-config subcommand's exit codes.
+// checkConfig calls os.Exit directly, so it's run out-of-process via the
+// TestCheckConfigHelperProcess re-exec below rather than called inline.
+func TestCheckConfig(t *testing.T) {
+	valid := writeCheckConfigFixture(t, `
+hosts:
+  mango.com:
+    pool: site-mango
+    upstreams:
+      - url: http://127.0.0.1:9001
+`)
+	invalid := writeCheckConfigFixture(t, `hosts: {}`)
+
+	cases := []struct {
+		name     string
+		args     []string
+		wantExit int
+	}{
+		{name: "valid config", args: []string{valid}, wantExit: 0},
+		{name: "invalid config", args: []string{invalid}, wantExit: 1},
+		{name: "missing path argument", args: nil, wantExit: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmdArgs := append([]string{"-test.run=TestCheckConfigHelperProcess", "--"}, tc.args...)
+			cmd := exec.Command(os.Args[0], cmdArgs...)
+			cmd.Env = append(os.Environ(), "GOADBALANCER_CHECKCONFIG_HELPER=1")
+
+			err := cmd.Run()
+			gotExit := 0
+			if err != nil {
+				exitErr, ok := err.(*exec.ExitError)
+				if !ok {
+					t.Fatalf("run helper process: %v", err)
+				}
+				gotExit = exitErr.ExitCode()
 			}
 
-			if body.Host == "" {
-				t.Fatalf("expected ost in response, got empty")
+			if gotExit != tc.wantExit {
+				t.Fatalf("exit code = %d, want %d", gotExit, tc.wantExit)
 			}
 		})
 	}
 }
+
+// TestCheckConfigHelperProcess isn't a real test: it's re-exec'd by
+// TestCheckConfig (guarded by an env var so `go test` doesn't run it on its
+// own) so checkConfig's os.Exit calls only terminate the child process.
+func TestCheckConfigHelperProcess(t *testing.T) {
+	if os.Getenv("GOADBALANCER_CHECKCONFIG_HELPER") != "1" {
+		return
+	}
+
+	args := os.Args
+	for i, a := range args {
+		if a == "--" {
+			checkConfig(args[i+1:])
+			return
+		}
+	}
+	checkConfig(nil)
+}
+
+func writeCheckConfigFixture(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+// testTable builds a RouteTable getter backed by throwaway httptest
+// upstreams, for tests that don't care which backend answered.
+func testTable(t *testing.T) func() *config.RouteTable {
+	t.Helper()
+	table, _ := testTableWithUpstreams(t)
+	return table
+}
+
+// testTableWithUpstreams builds a RouteTable getter identical in shape to
+// buildDemoTable, but pointed at fake upstreams so tests don't need real
+// network services running.
+func testTableWithUpstreams(t *testing.T) (func() *config.RouteTable, []*httptest.Server) {
+	t.Helper()
+
+	mango := fakeUpstream(t, "site-mango")
+	apple := fakeUpstream(t, "site-apple")
+
+	mangoBackend, err := pool.NewBackend(mango.URL, 1)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	appleBackend, err := pool.NewBackend(apple.URL, 1)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+
+	table := &config.RouteTable{
+		Pools: map[string]*pool.Pool{
+			"mango.com": pool.New("site-mango", &pool.RoundRobin{}, mangoBackend),
+			"apple.com": pool.New("site-apple", &pool.RoundRobin{}, appleBackend),
+		},
+		Passive: map[string]config.PassiveConfig{
+			"mango.com": {MaxFails: 1, FailTimeout: 10 * time.Second},
+			"apple.com": {MaxFails: 1, FailTimeout: 10 * time.Second},
+		},
+		Checks: map[string]health.ProbeConfig{},
+	}
+
+	return func() *config.RouteTable { return table }, []*httptest.Server{mango, apple}
+}
+
+func fakeUpstream(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Backend string `json:"backend"`
+		}{Backend: name})
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
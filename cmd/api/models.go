@@ -1,11 +1,7 @@
 package main
 
 type healthResponse struct {
-	Status string `json:"status"`
-	Time   string `json:"time"`
-}
-
-type hostResponse struct {
-	Host  string `json:"host"`
-	Route string `json:"route"`
+	Status string            `json:"status"`
+	Time   string            `json:"time"`
+	Pools  map[string]string `json:"pools,omitempty"`
 }
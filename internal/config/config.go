@@ -0,0 +1,477 @@
+// Package config loads the balancer's routing and middleware configuration
+// from a YAML file and keeps it fresh via Watch.
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/health"
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
+	"github.com/leo-jp-edwards/goad-balancer/internal/router"
+	"github.com/leo-jp-edwards/goad-balancer/internal/tlsconfig"
+)
+
+// Config is the top-level shape of the balancer's config file.
+type Config struct {
+	Hosts      map[string]HostConfig `yaml:"hosts"`
+	Timeouts   TimeoutsConfig        `yaml:"timeouts"`
+	Middleware MiddlewareConfig      `yaml:"middleware"`
+	TLS        *TLSConfig            `yaml:"tls"`
+}
+
+// TLSConfig turns on the :443 listener. Certs can come from a static
+// cert/key pair per SNI host, an ACME account, or both (static entries take
+// priority; ACME covers whatever they don't).
+type TLSConfig struct {
+	Certs        map[string]CertConfig `yaml:"certs"`
+	ACME         *ACMEConfig           `yaml:"acme"`
+	StrictSNI    bool                  `yaml:"strict_sni"`
+	RedirectHTTP bool                  `yaml:"redirect_http"`
+}
+
+// CertConfig is a static certificate/key file pair for one SNI host.
+type CertConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// ACMEConfig drives autocert for hosts with no static cert.
+type ACMEConfig struct {
+	Email        string   `yaml:"email"`
+	CacheDir     string   `yaml:"cache_dir"`
+	AllowedHosts []string `yaml:"allowed_hosts"`
+}
+
+// HostConfig describes one host's upstream pool, plus any path/method
+// routing rules layered on top of it.
+type HostConfig struct {
+	Pool        string             `yaml:"pool"`
+	Strategy    string             `yaml:"strategy"`
+	Upstreams   []UpstreamConfig   `yaml:"upstreams"`
+	HealthCheck *HealthCheckConfig `yaml:"health_check"`
+	Passive     *PassiveConfig     `yaml:"passive"`
+	Routes      []RouteConfig      `yaml:"routes"`
+}
+
+// RouteConfig is one path/method routing rule, with its own upstream pool.
+// Rules are evaluated in order; a host with no Routes just uses its
+// top-level Pool/Upstreams for every request, as before.
+type RouteConfig struct {
+	Method    string            `yaml:"method"`
+	Path      string            `yaml:"path"`
+	Match     string            `yaml:"match"` // "prefix" (default), "exact", or "regex"
+	Headers   map[string]string `yaml:"headers"`
+	Strategy  string            `yaml:"strategy"`
+	Upstreams []UpstreamConfig  `yaml:"upstreams"`
+	Rewrite   *RewriteConfig    `yaml:"rewrite"`
+}
+
+// RewriteConfig mirrors router.Rewrite.
+type RewriteConfig struct {
+	StripPrefix string `yaml:"strip_prefix"`
+	AddPrefix   string `yaml:"add_prefix"`
+	ReplacePath string `yaml:"replace_path"`
+}
+
+// HealthCheckConfig turns on active probing for a host's pool. Nil means
+// the host relies on passive detection alone.
+type HealthCheckConfig struct {
+	Method             string        `yaml:"method"`
+	Path               string        `yaml:"path"`
+	ExpectedStatus     int           `yaml:"expected_status"`
+	Timeout            time.Duration `yaml:"timeout"`
+	Interval           time.Duration `yaml:"interval"`
+	HealthyThreshold   int           `yaml:"healthy_threshold"`
+	UnhealthyThreshold int           `yaml:"unhealthy_threshold"`
+}
+
+// PassiveConfig mirrors nginx's upstream max_fails/fail_timeout: a backend
+// is taken out of rotation once it has failed MaxFails times within
+// FailTimeout.
+type PassiveConfig struct {
+	MaxFails    int           `yaml:"max_fails"`
+	FailTimeout time.Duration `yaml:"fail_timeout"`
+}
+
+// defaultPassive matches nginx's own upstream defaults.
+var defaultPassive = PassiveConfig{MaxFails: 1, FailTimeout: 10 * time.Second}
+
+// defaultHealthCheck fills in any zero-valued fields of an explicitly
+// configured health check.
+var defaultHealthCheck = HealthCheckConfig{
+	Method:             http.MethodGet,
+	Path:               "/health",
+	ExpectedStatus:     http.StatusOK,
+	Timeout:            2 * time.Second,
+	Interval:           5 * time.Second,
+	HealthyThreshold:   2,
+	UnhealthyThreshold: 2,
+}
+
+// UpstreamConfig is a single backend inside a host's pool.
+type UpstreamConfig struct {
+	URL    string `yaml:"url"`
+	Weight int    `yaml:"weight"`
+}
+
+// TimeoutsConfig holds the balancer's tunable timeouts.
+type TimeoutsConfig struct {
+	ReadHeader time.Duration `yaml:"read_header"`
+	Upstream   time.Duration `yaml:"upstream"`
+}
+
+// defaultTimeouts matches the balancer's previous hardcoded behavior: a 5s
+// header read timeout and no cap on how long a proxied request may run.
+var defaultTimeouts = TimeoutsConfig{ReadHeader: 5 * time.Second}
+
+// TimeoutsWithDefaults fills in any zero-valued fields of t with
+// defaultTimeouts.
+func TimeoutsWithDefaults(t TimeoutsConfig) TimeoutsConfig {
+	if t.ReadHeader == 0 {
+		t.ReadHeader = defaultTimeouts.ReadHeader
+	}
+	return t
+}
+
+// MiddlewareConfig toggles the opt-in middleware stack per deployment.
+// Each field is nil/zero when that middleware is disabled.
+type MiddlewareConfig struct {
+	AccessLog    *AccessLogConfig    `yaml:"access_log"`
+	Gzip         *GzipConfig         `yaml:"gzip"`
+	Recovery     bool                `yaml:"recovery"`
+	CORS         *CORSConfig         `yaml:"cors"`
+	ProxyHeaders *ProxyHeadersConfig `yaml:"proxy_headers"`
+}
+
+// AccessLogConfig configures where access logs are written. An empty Path
+// means stdout; otherwise logs rotate via the Max* settings.
+type AccessLogConfig struct {
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+}
+
+// GzipConfig mirrors middleware.GzipConfig.
+type GzipConfig struct {
+	ContentTypes []string `yaml:"content_types"`
+	MinSize      int      `yaml:"min_size"`
+}
+
+// CORSConfig mirrors middleware.CORSConfig.
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins"`
+	AllowedMethods   []string `yaml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"`
+}
+
+// ProxyHeadersConfig lists the CIDRs allowed to set forwarding headers.
+type ProxyHeadersConfig struct {
+	TrustedCIDRs []string `yaml:"trusted_cidrs"`
+}
+
+// Load reads and parses the config file at path, and validates it.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks the config is well-formed enough to build a route table
+// from: every host needs a known strategy and at least one upstream.
+func (c *Config) Validate() error {
+	if len(c.Hosts) == 0 {
+		return fmt.Errorf("no hosts configured")
+	}
+
+	for host, hc := range c.Hosts {
+		if len(hc.Upstreams) == 0 {
+			return fmt.Errorf("host %q: no upstreams configured", host)
+		}
+
+		if hc.Strategy != "" {
+			if _, err := newStrategy(hc.Strategy); err != nil {
+				return fmt.Errorf("host %q: %w", host, err)
+			}
+		}
+
+		for _, up := range hc.Upstreams {
+			if up.URL == "" {
+				return fmt.Errorf("host %q: upstream missing url", host)
+			}
+		}
+
+		for i, route := range hc.Routes {
+			if len(route.Upstreams) == 0 {
+				return fmt.Errorf("host %q: route %d: no upstreams configured", host, i)
+			}
+			if _, err := matchTypeFromString(route.Match); err != nil {
+				return fmt.Errorf("host %q: route %d: %w", host, i, err)
+			}
+			if route.Strategy != "" {
+				if _, err := newStrategy(route.Strategy); err != nil {
+					return fmt.Errorf("host %q: route %d: %w", host, i, err)
+				}
+			}
+		}
+	}
+
+	if c.TLS != nil {
+		if len(c.TLS.Certs) == 0 && c.TLS.ACME == nil {
+			return fmt.Errorf("tls: no certs and no acme configured")
+		}
+		for host, cc := range c.TLS.Certs {
+			if cc.CertFile == "" || cc.KeyFile == "" {
+				return fmt.Errorf("tls: host %q: cert_file and key_file are both required", host)
+			}
+		}
+		if c.TLS.ACME != nil && len(c.TLS.ACME.AllowedHosts) == 0 {
+			return fmt.Errorf("tls: acme: allowed_hosts must list at least one host")
+		}
+	}
+
+	return nil
+}
+
+func matchTypeFromString(s string) (router.MatchType, error) {
+	switch s {
+	case "", "prefix":
+		return router.MatchPrefix, nil
+	case "exact":
+		return router.MatchExact, nil
+	case "regex":
+		return router.MatchRegex, nil
+	default:
+		return 0, fmt.Errorf("unknown match type %q", s)
+	}
+}
+
+// RouteTable is the built, ready-to-serve form of a Config: one Pool per
+// host, plus the passive-detection and active health-check settings that
+// apply to it. Watcher swaps these in atomically as new config generations
+// load.
+type RouteTable struct {
+	Pools   map[string]*pool.Pool
+	Passive map[string]PassiveConfig
+	Checks  map[string]health.ProbeConfig
+	// Routers holds the path/method routing layer for hosts that declared
+	// Routes. A host absent from this map has none, and callers should
+	// just use Pools[host] directly, as before the routing layer existed.
+	Routers map[string]*router.Router
+	// Timeouts holds the balancer's tunable timeouts, with defaults
+	// already applied. The handler uses Timeouts.Upstream to bound each
+	// proxied request; Timeouts.ReadHeader is for main to apply to the
+	// listening http.Server, since that happens before any request (and
+	// any RouteTable) exists.
+	Timeouts TimeoutsConfig
+}
+
+// BuildRouteTable constructs a RouteTable from the config, creating a Pool
+// (and its Backends) per host, and a Router per host that declares Routes.
+func (c *Config) BuildRouteTable() (*RouteTable, error) {
+	table := &RouteTable{
+		Pools:    make(map[string]*pool.Pool, len(c.Hosts)),
+		Passive:  make(map[string]PassiveConfig, len(c.Hosts)),
+		Checks:   make(map[string]health.ProbeConfig, len(c.Hosts)),
+		Routers:  make(map[string]*router.Router, len(c.Hosts)),
+		Timeouts: TimeoutsWithDefaults(c.Timeouts),
+	}
+
+	for host, hc := range c.Hosts {
+		defaultPool, err := buildPool(host, hc.Pool, hc.Strategy, hc.Upstreams)
+		if err != nil {
+			return nil, err
+		}
+
+		table.Pools[host] = defaultPool
+		table.Passive[host] = passiveWithDefaults(hc.Passive)
+
+		if hc.HealthCheck != nil {
+			table.Checks[host] = probeConfigWithDefaults(*hc.HealthCheck)
+		}
+
+		if len(hc.Routes) > 0 {
+			rt, err := buildRouter(host, hc.Routes, defaultPool)
+			if err != nil {
+				return nil, err
+			}
+			table.Routers[host] = rt
+		}
+	}
+
+	return table, nil
+}
+
+// BuildTLSManager constructs the tlsconfig.Manager described by cfg, or nil
+// if cfg is nil.
+func BuildTLSManager(cfg *TLSConfig) (*tlsconfig.Manager, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	certs := make(map[string]tlsconfig.CertPair, len(cfg.Certs))
+	for host, cc := range cfg.Certs {
+		certs[host] = tlsconfig.CertPair{CertFile: cc.CertFile, KeyFile: cc.KeyFile}
+	}
+
+	var acme *tlsconfig.ACMEConfig
+	if cfg.ACME != nil {
+		acme = &tlsconfig.ACMEConfig{
+			Email:        cfg.ACME.Email,
+			CacheDir:     cfg.ACME.CacheDir,
+			AllowedHosts: cfg.ACME.AllowedHosts,
+		}
+	}
+
+	return tlsconfig.NewManager(certs, acme, cfg.StrictSNI)
+}
+
+func buildPool(host, name, strategyName string, upstreams []UpstreamConfig) (*pool.Pool, error) {
+	if strategyName == "" {
+		strategyName = "round_robin"
+	}
+
+	strategy, err := newStrategy(strategyName)
+	if err != nil {
+		return nil, fmt.Errorf("host %q: %w", host, err)
+	}
+
+	backends := make([]*pool.Backend, 0, len(upstreams))
+	for _, up := range upstreams {
+		backend, err := pool.NewBackend(up.URL, up.Weight)
+		if err != nil {
+			return nil, fmt.Errorf("host %q: upstream %q: %w", host, up.URL, err)
+		}
+		backends = append(backends, backend)
+	}
+
+	if name == "" {
+		name = host
+	}
+
+	return pool.New(name, strategy, backends...), nil
+}
+
+func buildRouter(host string, routes []RouteConfig, defaultPool *pool.Pool) (*router.Router, error) {
+	rules := make([]router.Rule, 0, len(routes))
+
+	for i, rc := range routes {
+		p, err := buildPool(host, fmt.Sprintf("%s-route-%d", host, i), rc.Strategy, rc.Upstreams)
+		if err != nil {
+			return nil, err
+		}
+
+		matchType, err := matchTypeFromString(rc.Match)
+		if err != nil {
+			return nil, fmt.Errorf("host %q: route %d: %w", host, i, err)
+		}
+
+		rules = append(rules, router.Rule{
+			Method:  rc.Method,
+			Path:    rc.Path,
+			Type:    matchType,
+			Headers: rc.Headers,
+			Pool:    p,
+			Rewrite: rewriteFromConfig(rc.Rewrite),
+		})
+	}
+
+	rt, err := router.New(rules, defaultPool)
+	if err != nil {
+		return nil, fmt.Errorf("host %q: %w", host, err)
+	}
+	return rt, nil
+}
+
+func rewriteFromConfig(rc *RewriteConfig) *router.Rewrite {
+	if rc == nil {
+		return nil
+	}
+	return &router.Rewrite{
+		StripPrefix: rc.StripPrefix,
+		AddPrefix:   rc.AddPrefix,
+		ReplacePath: rc.ReplacePath,
+	}
+}
+
+func passiveWithDefaults(pc *PassiveConfig) PassiveConfig {
+	if pc == nil {
+		return defaultPassive
+	}
+
+	out := *pc
+	if out.MaxFails == 0 {
+		out.MaxFails = defaultPassive.MaxFails
+	}
+	if out.FailTimeout == 0 {
+		out.FailTimeout = defaultPassive.FailTimeout
+	}
+	return out
+}
+
+func probeConfigWithDefaults(hc HealthCheckConfig) health.ProbeConfig {
+	if hc.Method == "" {
+		hc.Method = defaultHealthCheck.Method
+	}
+	if hc.Path == "" {
+		hc.Path = defaultHealthCheck.Path
+	}
+	if hc.ExpectedStatus == 0 {
+		hc.ExpectedStatus = defaultHealthCheck.ExpectedStatus
+	}
+	if hc.Timeout == 0 {
+		hc.Timeout = defaultHealthCheck.Timeout
+	}
+	if hc.Interval == 0 {
+		hc.Interval = defaultHealthCheck.Interval
+	}
+	if hc.HealthyThreshold == 0 {
+		hc.HealthyThreshold = defaultHealthCheck.HealthyThreshold
+	}
+	if hc.UnhealthyThreshold == 0 {
+		hc.UnhealthyThreshold = defaultHealthCheck.UnhealthyThreshold
+	}
+
+	return health.ProbeConfig{
+		Method:             hc.Method,
+		Path:               hc.Path,
+		ExpectedStatus:     hc.ExpectedStatus,
+		Timeout:            hc.Timeout,
+		Interval:           hc.Interval,
+		HealthyThreshold:   hc.HealthyThreshold,
+		UnhealthyThreshold: hc.UnhealthyThreshold,
+	}
+}
+
+func newStrategy(name string) (pool.Strategy, error) {
+	switch name {
+	case "round_robin":
+		return &pool.RoundRobin{}, nil
+	case "weighted_round_robin":
+		return &pool.WeightedRoundRobin{}, nil
+	case "least_connections":
+		return &pool.LeastConnections{}, nil
+	case "ip_hash":
+		return &pool.IPHash{}, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", name)
+	}
+}
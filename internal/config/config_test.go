@@ -0,0 +1,175 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadAndBuildRouteTable(t *testing.T) {
+	path := writeConfig(t, `
+hosts:
+  mango.com:
+    pool: site-mango
+    strategy: round_robin
+    upstreams:
+      - url: http://127.0.0.1:9001
+        weight: 1
+      - url: http://127.0.0.1:9003
+        weight: 2
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	table, err := cfg.BuildRouteTable()
+	if err != nil {
+		t.Fatalf("BuildRouteTable: %v", err)
+	}
+
+	p, ok := table.Pools["mango.com"]
+	if !ok {
+		t.Fatalf("expected pool for mango.com")
+	}
+
+	if len(p.Backends) != 2 {
+		t.Fatalf("expected 2 backends, got %d", len(p.Backends))
+	}
+}
+
+func TestBuildRouteTableWithRoutes(t *testing.T) {
+	path := writeConfig(t, `
+hosts:
+  mango.com:
+    pool: site-mango
+    upstreams:
+      - url: http://127.0.0.1:9001
+    routes:
+      - method: GET
+        path: /api/
+        match: prefix
+        upstreams:
+          - url: http://127.0.0.1:9010
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	table, err := cfg.BuildRouteTable()
+	if err != nil {
+		t.Fatalf("BuildRouteTable: %v", err)
+	}
+
+	rt, ok := table.Routers["mango.com"]
+	if !ok {
+		t.Fatalf("expected a router for mango.com")
+	}
+
+	p, _ := rt.Match(httptest.NewRequest(http.MethodGet, "/api/widgets", nil))
+	if len(p.Backends) != 1 || p.Backends[0].URL.String() != "http://127.0.0.1:9010" {
+		t.Fatalf("expected the /api/ route's own pool, got %+v", p)
+	}
+
+	p, _ = rt.Match(httptest.NewRequest(http.MethodGet, "/other", nil))
+	if p != table.Pools["mango.com"] {
+		t.Fatalf("expected unmatched paths to fall back to the host's default pool")
+	}
+}
+
+func TestBuildTLSManager(t *testing.T) {
+	manager, err := BuildTLSManager(nil)
+	if err != nil {
+		t.Fatalf("BuildTLSManager(nil): %v", err)
+	}
+	if manager != nil {
+		t.Fatalf("expected a nil manager when TLS isn't configured")
+	}
+
+	manager, err = BuildTLSManager(&TLSConfig{
+		ACME: &ACMEConfig{Email: "admin@mango.com", CacheDir: t.TempDir(), AllowedHosts: []string{"mango.com"}},
+	})
+	if err != nil {
+		t.Fatalf("BuildTLSManager: %v", err)
+	}
+	if manager == nil {
+		t.Fatalf("expected a manager when acme is configured")
+	}
+}
+
+func TestLoadRejectsBadConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+	}{
+		{
+			name:     "no hosts",
+			contents: `hosts: {}`,
+		},
+		{
+			name: "host with no upstreams",
+			contents: `
+hosts:
+  mango.com:
+    upstreams: []
+`,
+		},
+		{
+			name: "unknown strategy",
+			contents: `
+hosts:
+  mango.com:
+    strategy: made-up
+    upstreams:
+      - url: http://127.0.0.1:9001
+`,
+		},
+		{
+			name: "tls with no certs and no acme",
+			contents: `
+hosts:
+  mango.com:
+    upstreams:
+      - url: http://127.0.0.1:9001
+tls: {}
+`,
+		},
+		{
+			name: "tls acme with no allowed hosts",
+			contents: `
+hosts:
+  mango.com:
+    upstreams:
+      - url: http://127.0.0.1:9001
+tls:
+  acme:
+    email: admin@mango.com
+    cache_dir: /tmp/acme-cache
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfig(t, tc.contents)
+			if _, err := Load(path); err == nil {
+				t.Fatalf("expected an error, got nil")
+			}
+		})
+	}
+}
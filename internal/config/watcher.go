@@ -0,0 +1,144 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher owns the balancer's live config: it loads path once up front and
+// then re-loads it on SIGHUP or file-mtime change, publishing each new
+// generation behind an atomic pointer so in-flight requests keep using
+// whichever RouteTable they started with.
+type Watcher struct {
+	path     string
+	cfg      atomic.Pointer[Config]
+	table    atomic.Pointer[RouteTable]
+	reloaded chan struct{}
+}
+
+// NewWatcher loads path and returns a Watcher ready to serve from it. Call
+// Watch to start picking up reloads.
+func NewWatcher(path string) (*Watcher, error) {
+	w := &Watcher{path: path, reloaded: make(chan struct{}, 1)}
+
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := cfg.BuildRouteTable()
+	if err != nil {
+		return nil, err
+	}
+
+	w.cfg.Store(cfg)
+	w.table.Store(table)
+	return w, nil
+}
+
+// Table returns the currently active RouteTable.
+func (w *Watcher) Table() *RouteTable {
+	return w.table.Load()
+}
+
+// Config returns the currently active Config, e.g. to read middleware
+// settings that aren't part of the RouteTable itself.
+func (w *Watcher) Config() *Config {
+	return w.cfg.Load()
+}
+
+// Reloaded fires (non-blocking, single-slot) after every successful
+// reload, so callers can re-target anything keyed off the old RouteTable
+// (like active health checks).
+func (w *Watcher) Reloaded() <-chan struct{} {
+	return w.reloaded
+}
+
+// Watch blocks, reloading the config on SIGHUP or whenever path's mtime
+// changes, until ctx is cancelled.
+func (w *Watcher) Watch(ctx context.Context) error {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		// File-mtime watching is a nice-to-have; SIGHUP still works without it.
+		log.Printf("config: fsnotify unavailable, falling back to SIGHUP-only reload: %v", err)
+		fsw = nil
+	} else {
+		defer fsw.Close()
+		// Watch the parent directory rather than the file itself: editors
+		// and config-management tools typically replace a config file by
+		// writing a temp file and renaming it over the original, which
+		// delivers a Rename/Remove event (not Write) for w.path and leaves
+		// an inode-based watch on it pointing at nothing. The directory's
+		// inode doesn't change across a rename, so this watch keeps working
+		// for the life of the process.
+		dir := filepath.Dir(w.path)
+		if err := fsw.Add(dir); err != nil {
+			log.Printf("config: watching %s failed: %v", dir, err)
+		}
+	}
+	base := filepath.Base(w.path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-sighup:
+			w.reload()
+
+		case event := <-fsnotifyEvents(fsw):
+			if filepath.Base(event.Name) != base {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				// Editors often replace-then-rename; give the write a moment to land.
+				time.Sleep(50 * time.Millisecond)
+				w.reload()
+			}
+		}
+	}
+}
+
+// fsnotifyEvents returns fsw's Events channel, or nil (which blocks
+// forever in a select) when fsw is nil.
+func fsnotifyEvents(fsw *fsnotify.Watcher) chan fsnotify.Event {
+	if fsw == nil {
+		return nil
+	}
+	return fsw.Events
+}
+
+func (w *Watcher) reload() {
+	cfg, err := Load(w.path)
+	if err != nil {
+		log.Printf("config: reload of %s failed, keeping previous config: %v", w.path, err)
+		return
+	}
+
+	table, err := cfg.BuildRouteTable()
+	if err != nil {
+		log.Printf("config: reload of %s failed, keeping previous config: %v", w.path, err)
+		return
+	}
+
+	w.cfg.Store(cfg)
+	w.table.Store(table)
+	log.Printf("config: reloaded %s", w.path)
+
+	select {
+	case w.reloaded <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func waitForReload(t *testing.T, w *Watcher) {
+	t.Helper()
+	select {
+	case <-w.Reloaded():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for reload")
+	}
+}
+
+func TestWatcherReloadsOnSIGHUP(t *testing.T) {
+	path := writeConfig(t, `
+hosts:
+  mango.com:
+    pool: site-mango
+    upstreams:
+      - url: http://127.0.0.1:9001
+`)
+
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = w.Watch(ctx) }()
+
+	if err := os.WriteFile(path, []byte(`
+hosts:
+  mango.com:
+    pool: site-mango
+    upstreams:
+      - url: http://127.0.0.1:9002
+`), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("send SIGHUP: %v", err)
+	}
+	waitForReload(t, w)
+
+	p := w.Table().Pools["mango.com"]
+	if len(p.Backends) != 1 || p.Backends[0].URL.String() != "http://127.0.0.1:9002" {
+		t.Fatalf("expected reloaded config to take effect, got %+v", p)
+	}
+}
+
+// TestWatcherReloadsOnAtomicRename exercises the editor/kubectl pattern of
+// replacing a config file by writing a temp file alongside it and renaming
+// it over the original: that delivers a Rename/Remove event for w.path, not
+// a Write, and the watch has to be on the parent directory (not the file's
+// own inode) to ever see it.
+func TestWatcherReloadsOnAtomicRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	if err := os.WriteFile(path, []byte(`
+hosts:
+  mango.com:
+    pool: site-mango
+    upstreams:
+      - url: http://127.0.0.1:9001
+`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = w.Watch(ctx) }()
+
+	tmp := filepath.Join(dir, ".config.yaml.tmp")
+	if err := os.WriteFile(tmp, []byte(`
+hosts:
+  mango.com:
+    pool: site-mango
+    upstreams:
+      - url: http://127.0.0.1:9002
+`), 0o600); err != nil {
+		t.Fatalf("write replacement config: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("rename replacement config over original: %v", err)
+	}
+	waitForReload(t, w)
+
+	p := w.Table().Pools["mango.com"]
+	if len(p.Backends) != 1 || p.Backends[0].URL.String() != "http://127.0.0.1:9002" {
+		t.Fatalf("expected the renamed-in config to take effect, got %+v", p)
+	}
+}
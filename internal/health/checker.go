@@ -0,0 +1,86 @@
+// Package health implements active upstream health checking: a periodic
+// probe per backend that flips it out of rotation after enough consecutive
+// failures and back in after enough consecutive successes. Passive
+// detection (5xx / dial errors seen on the proxied path) lives on
+// pool.Backend itself, since it's driven from the request path rather than
+// a background loop.
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
+)
+
+// ProbeConfig describes how to actively health-check a single host's pool.
+type ProbeConfig struct {
+	Method             string
+	Path               string
+	ExpectedStatus     int
+	Timeout            time.Duration
+	Interval           time.Duration
+	HealthyThreshold   int
+	UnhealthyThreshold int
+}
+
+var probeClient = &http.Client{}
+
+// Run probes backend on cfg.Interval until ctx is cancelled, flipping its
+// healthy state once cfg.HealthyThreshold or cfg.UnhealthyThreshold
+// consecutive results have been seen.
+func Run(ctx context.Context, backend *pool.Backend, cfg ProbeConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	var consecutiveSuccesses, consecutiveFailures int
+
+	probe := func() {
+		if probeOnce(ctx, backend, cfg) {
+			consecutiveSuccesses++
+			consecutiveFailures = 0
+			if consecutiveSuccesses >= cfg.HealthyThreshold {
+				backend.SetHealthy(true)
+			}
+			return
+		}
+
+		consecutiveFailures++
+		consecutiveSuccesses = 0
+		if consecutiveFailures >= cfg.UnhealthyThreshold {
+			backend.SetHealthy(false)
+		}
+	}
+
+	probe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			probe()
+		}
+	}
+}
+
+func probeOnce(ctx context.Context, backend *pool.Backend, cfg ProbeConfig) bool {
+	reqCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	target := backend.URL.ResolveReference(&url.URL{Path: cfg.Path})
+
+	req, err := http.NewRequestWithContext(reqCtx, cfg.Method, target.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == cfg.ExpectedStatus
+}
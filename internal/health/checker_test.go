@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
+)
+
+func TestRunFlipsBackendOnProbeResults(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthy.Load() {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	backend, err := pool.NewBackend(upstream.URL, 1)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+
+	cfg := ProbeConfig{
+		Method:             http.MethodGet,
+		Path:               "/probe",
+		ExpectedStatus:     http.StatusOK,
+		Timeout:            time.Second,
+		Interval:           10 * time.Millisecond,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go Run(ctx, backend, cfg)
+
+	waitFor(t, func() bool { return backend.Healthy() })
+
+	healthy.Store(false)
+	waitFor(t, func() bool { return !backend.Healthy() })
+
+	healthy.Store(true)
+	waitFor(t, func() bool { return backend.Healthy() })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before deadline")
+}
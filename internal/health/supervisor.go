@@ -0,0 +1,58 @@
+package health
+
+import (
+	"context"
+	"sync"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
+)
+
+// Supervisor runs active probes for a set of pools and can be re-targeted
+// at a new set (e.g. after a config reload), cancelling the previous
+// generation's probes first.
+type Supervisor struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewSupervisor returns an idle Supervisor; call Retarget to start probing.
+func NewSupervisor() *Supervisor {
+	return &Supervisor{}
+}
+
+// Retarget stops any probes from a previous generation and starts one
+// goroutine per backend for every host that has a ProbeConfig in checks.
+// Hosts absent from checks are left to passive detection only.
+func (s *Supervisor) Retarget(pools map[string]*pool.Pool, checks map[string]ProbeConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for host, p := range pools {
+		cfg, ok := checks[host]
+		if !ok {
+			continue
+		}
+
+		for _, backend := range p.Backends {
+			go Run(ctx, backend, cfg)
+		}
+	}
+}
+
+// Stop cancels any running probes.
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
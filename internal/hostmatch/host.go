@@ -0,0 +1,25 @@
+// Package hostmatch provides the host-name canonicalization shared by HTTP
+// routing and TLS SNI lookups, so both agree on what "mango.com" means
+// regardless of port or case.
+package hostmatch
+
+import (
+	"net"
+	"strings"
+)
+
+// Canonical strips an optional :port and normalises case; it also
+// tolerates bracketed IPv6 hosts like "[::1]:8080".
+func Canonical(h string) string {
+	h = strings.TrimSpace(strings.ToLower(h))
+	if h == "" {
+		return ""
+	}
+	// if it looks like host:port or [v6]:port, split
+	if host, _, err := net.SplitHostPort(h); err == nil {
+		// net.SplitHostPort keeps brackets off the returned host for IPv6
+		return strings.Trim(host, "[]")
+	}
+	// otherwise it may already be a host
+	return strings.Trim(h, "[]")
+}
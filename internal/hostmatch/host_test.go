@@ -0,0 +1,23 @@
+package hostmatch
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"Mango.com", "mango.com"},
+		{"mango.com:8080", "mango.com"},
+		{"[::1]:8080", "::1"},
+		{"[::1]", "::1"},
+		{"", ""},
+		{"  Apple.COM  ", "apple.com"},
+	}
+
+	for _, tc := range cases {
+		if got := Canonical(tc.in); got != tc.want {
+			t.Errorf("Canonical(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
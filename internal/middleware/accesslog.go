@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// AccessLog logs every request to w in the Apache combined log format.
+// Callers pass os.Stdout for console logging or an io.Writer backed by a
+// rotating file.
+func AccessLog(w io.Writer) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			lrw := &loggingResponseWriter{ResponseWriter: rw, status: http.StatusOK}
+
+			next.ServeHTTP(lrw, r)
+
+			clientIP := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(clientIP); err == nil {
+				clientIP = host
+			}
+
+			fmt.Fprintf(w, "%s - - [%s] %q %d %d %q %q\n",
+				clientIP,
+				start.Format("02/Jan/2006:15:04:05 -0700"),
+				fmt.Sprintf("%s %s %s", r.Method, r.RequestURI, r.Proto),
+				lrw.status,
+				lrw.size,
+				r.Referer(),
+				r.UserAgent(),
+			)
+		})
+	}
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *loggingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
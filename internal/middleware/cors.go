@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CORSConfig configures allowed origins, methods, and headers.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+}
+
+// CORS handles cross-origin requests, including preflight OPTIONS
+// requests, according to cfg.
+func CORS(cfg CORSConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !cfg.originAllowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", strings.Join(cfg.methodsOrDefault(), ", "))
+				if headers := cfg.headersOrDefault(r); len(headers) > 0 {
+					h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (cfg CORSConfig) originAllowed(origin string) bool {
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg CORSConfig) methodsOrDefault() []string {
+	if len(cfg.AllowedMethods) > 0 {
+		return cfg.AllowedMethods
+	}
+	return []string{
+		http.MethodGet, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions,
+	}
+}
+
+func (cfg CORSConfig) headersOrDefault(r *http.Request) []string {
+	if len(cfg.AllowedHeaders) > 0 {
+		return cfg.AllowedHeaders
+	}
+	if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		return []string{requested}
+	}
+	return nil
+}
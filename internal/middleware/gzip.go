@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// GzipConfig controls which responses Gzip compresses.
+type GzipConfig struct {
+	// ContentTypes is the allowlist of Content-Type prefixes eligible for
+	// compression. An empty list allows every content type.
+	ContentTypes []string
+	// MinSize is the smallest response body, in bytes, worth compressing.
+	MinSize int
+}
+
+// Gzip compresses responses whose Content-Type matches cfg.ContentTypes
+// and whose body is at least cfg.MinSize bytes, when the client sent
+// Accept-Encoding: gzip. Responses are buffered in full so the decision
+// can be made after the handler has written its Content-Type and body.
+func Gzip(cfg GzipConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, cfg: cfg}
+			next.ServeHTTP(gw, r)
+			gw.flush()
+		})
+	}
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	cfg    GzipConfig
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *gzipResponseWriter) flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	body := w.buf.Bytes()
+	if !w.eligible(w.Header().Get("Content-Type"), len(body)) {
+		w.ResponseWriter.WriteHeader(w.status)
+		_, _ = w.ResponseWriter.Write(body)
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	gz := gzip.NewWriter(w.ResponseWriter)
+	_, _ = gz.Write(body)
+	_ = gz.Close()
+}
+
+func (w *gzipResponseWriter) eligible(contentType string, size int) bool {
+	if size < w.cfg.MinSize {
+		return false
+	}
+
+	if len(w.cfg.ContentTypes) == 0 {
+		return true
+	}
+
+	for _, ct := range w.cfg.ContentTypes {
+		if strings.HasPrefix(contentType, ct) {
+			return true
+		}
+	}
+	return false
+}
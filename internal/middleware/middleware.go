@@ -0,0 +1,18 @@
+// Package middleware provides a composable, opt-in HTTP middleware chain
+// for the balancer: access logging, gzip compression, panic recovery,
+// CORS, and trusted-proxy header handling.
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler with additional behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies mws to h in order, so the first Middleware in the list is
+// the outermost (it sees the request first and the response last).
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ProxyHeaders rewrites r.RemoteAddr and r.Host from X-Forwarded-For,
+// X-Forwarded-Host, and RFC 7239 Forwarded headers, but only when the
+// direct peer's address falls inside one of trustedCIDRs -- otherwise a
+// client could spoof its own address simply by setting these headers.
+// It must run before hostmatch.Canonical (or anything else keyed off r.Host)
+// sees the request.
+func ProxyHeaders(trustedCIDRs []string) (Middleware, error) {
+	nets, err := parseCIDRs(trustedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("proxy headers: %w", err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !trustedPeer(r.RemoteAddr, nets) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if fwd := r.Header.Get("Forwarded"); fwd != "" {
+				if addr, host, ok := parseForwarded(fwd); ok {
+					if addr != "" {
+						r.RemoteAddr = addr
+					}
+					if host != "" {
+						r.Host = host
+					}
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+				r.RemoteAddr = strings.TrimSpace(strings.Split(xff, ",")[0])
+			}
+			if xfh := r.Header.Get("X-Forwarded-Host"); xfh != "" {
+				r.Host = xfh
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted CIDR %q: %w", c, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func trustedPeer(remoteAddr string, nets []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwarded extracts the for= and host= params from the first
+// element of an RFC 7239 Forwarded header.
+func parseForwarded(header string) (addr, host string, ok bool) {
+	first := strings.Split(header, ",")[0]
+
+	for _, part := range strings.Split(first, ";") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		val := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+
+		switch key {
+		case "for":
+			addr = val
+			ok = true
+		case "host":
+			host = val
+			ok = true
+		}
+	}
+
+	return addr, host, ok
+}
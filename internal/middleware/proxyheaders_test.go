@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyHeadersRewritesFromTrustedPeer(t *testing.T) {
+	mw, err := ProxyHeaders([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ProxyHeaders: %v", err)
+	}
+
+	var gotHost, gotRemoteAddr string
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		gotRemoteAddr = r.RemoteAddr
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+	req.Header.Set("X-Forwarded-Host", "app.example.com")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotHost != "app.example.com" {
+		t.Fatalf("unexpected host: got %q want %q", gotHost, "app.example.com")
+	}
+	if gotRemoteAddr != "203.0.113.5" {
+		t.Fatalf("unexpected remote addr: got %q want %q", gotRemoteAddr, "203.0.113.5")
+	}
+}
+
+func TestProxyHeadersIgnoresUntrustedPeer(t *testing.T) {
+	mw, err := ProxyHeaders([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ProxyHeaders: %v", err)
+	}
+
+	var gotHost, gotRemoteAddr string
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		gotRemoteAddr = r.RemoteAddr
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.1:12345"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	req.Header.Set("X-Forwarded-Host", "spoofed.example.com")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotHost != "example.com" {
+		t.Fatalf("expected untrusted peer's headers to be ignored, got host %q", gotHost)
+	}
+	if gotRemoteAddr != "203.0.113.1:12345" {
+		t.Fatalf("expected RemoteAddr unchanged, got %q", gotRemoteAddr)
+	}
+}
+
+func TestProxyHeadersForwardedHeader(t *testing.T) {
+	mw, err := ProxyHeaders([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ProxyHeaders: %v", err)
+	}
+
+	var gotHost, gotRemoteAddr string
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		gotRemoteAddr = r.RemoteAddr
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("Forwarded", `for=203.0.113.5;host=app.example.com;proto=https`)
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotHost != "app.example.com" {
+		t.Fatalf("unexpected host: got %q want %q", gotHost, "app.example.com")
+	}
+	if gotRemoteAddr != "203.0.113.5" {
+		t.Fatalf("unexpected remote addr: got %q want %q", gotRemoteAddr, "203.0.113.5")
+	}
+}
@@ -0,0 +1,93 @@
+// Package pool implements upstream backend pools and the load-balancing
+// strategies used to pick a backend for a given request.
+package pool
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Backend is a single upstream target inside a Pool.
+type Backend struct {
+	URL    *url.URL
+	Weight int
+
+	healthy  atomic.Bool
+	inFlight atomic.Int64
+
+	mu          sync.Mutex
+	recentFails []time.Time
+}
+
+// NewBackend builds a Backend pointing at rawURL with the given weight.
+// A weight of 0 is treated as 1 by the weighted strategies. Backends start
+// out healthy; health checking (if enabled) takes it from there.
+func NewBackend(rawURL string, weight int) (*Backend, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Backend{URL: u, Weight: weight}
+	b.healthy.Store(true)
+	return b, nil
+}
+
+// Healthy reports whether the backend is currently eligible for traffic.
+func (b *Backend) Healthy() bool {
+	return b.healthy.Load()
+}
+
+// SetHealthy marks the backend healthy or unhealthy.
+func (b *Backend) SetHealthy(healthy bool) {
+	b.healthy.Store(healthy)
+}
+
+// InFlight returns the number of requests currently in progress on this
+// backend, used by the least-connections strategy.
+func (b *Backend) InFlight() int64 {
+	return b.inFlight.Load()
+}
+
+// StartRequest increments the in-flight counter and returns a func that
+// decrements it again; callers should defer the returned func.
+func (b *Backend) StartRequest() func() {
+	b.inFlight.Add(1)
+	return func() { b.inFlight.Add(-1) }
+}
+
+// RecordFailure is the passive half of health checking (the nginx upstream
+// model): it notes a proxied 5xx or dial/read error against the backend,
+// and takes it out of rotation immediately once maxFails such failures
+// have landed within failTimeout.
+func (b *Backend) RecordFailure(maxFails int, failTimeout time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-failTimeout)
+
+	kept := b.recentFails[:0]
+	for _, t := range b.recentFails {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	kept = append(kept, now)
+	b.recentFails = kept
+
+	if len(b.recentFails) >= maxFails {
+		b.healthy.Store(false)
+	}
+}
+
+// RecordSuccess clears the passive failure window after a good response;
+// active health checks, if configured, are still what brings a backend
+// back into rotation once it's been marked unhealthy.
+func (b *Backend) RecordSuccess() {
+	b.mu.Lock()
+	b.recentFails = nil
+	b.mu.Unlock()
+}
@@ -0,0 +1,73 @@
+package pool
+
+import "net/http"
+
+// Pool is a named group of backends fronted by a single load-balancing
+// Strategy. A Pool is safe for concurrent use.
+type Pool struct {
+	Name     string
+	Backends []*Backend
+	Strategy Strategy
+}
+
+// New builds a Pool with the given name and strategy.
+func New(name string, strategy Strategy, backends ...*Backend) *Pool {
+	return &Pool{Name: name, Backends: backends, Strategy: strategy}
+}
+
+// Next returns a healthy backend to send the request to, or nil if every
+// backend in the pool is currently unhealthy.
+func (p *Pool) Next(r *http.Request) *Backend {
+	healthy := make([]*Backend, 0, len(p.Backends))
+	for _, b := range p.Backends {
+		if b.Healthy() {
+			healthy = append(healthy, b)
+		}
+	}
+
+	return p.Strategy.Next(healthy, r.RemoteAddr)
+}
+
+// State is a JSON-friendly snapshot of a single backend, used by the
+// /admin/pools endpoint.
+type State struct {
+	URL      string `json:"url"`
+	Weight   int    `json:"weight"`
+	Healthy  bool   `json:"healthy"`
+	InFlight int64  `json:"in_flight"`
+}
+
+// Status reports the pool's aggregate health: "unhealthy" when every
+// backend is down, "degraded" when some but not all are, and "ok" when
+// every backend is healthy.
+func (p *Pool) Status() string {
+	healthy := 0
+	for _, b := range p.Backends {
+		if b.Healthy() {
+			healthy++
+		}
+	}
+
+	switch {
+	case healthy == 0:
+		return "unhealthy"
+	case healthy < len(p.Backends):
+		return "degraded"
+	default:
+		return "ok"
+	}
+}
+
+// Snapshot returns the current state of every backend in the pool.
+func (p *Pool) Snapshot() []State {
+	states := make([]State, 0, len(p.Backends))
+	for _, b := range p.Backends {
+		states = append(states, State{
+			URL:      b.URL.String(),
+			Weight:   b.Weight,
+			Healthy:  b.Healthy(),
+			InFlight: b.InFlight(),
+		})
+	}
+	return states
+}
@@ -0,0 +1,69 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackendRecordFailureMarksUnhealthyAfterMaxFails(t *testing.T) {
+	b, err := NewBackend("http://127.0.0.1:9999", 1)
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+
+	b.RecordFailure(2, time.Minute)
+	if !b.Healthy() {
+		t.Fatalf("expected backend to still be healthy after one failure")
+	}
+
+	b.RecordFailure(2, time.Minute)
+	if b.Healthy() {
+		t.Fatalf("expected backend to be unhealthy after max_fails failures")
+	}
+}
+
+func TestBackendRecordFailureWindowExpires(t *testing.T) {
+	b, err := NewBackend("http://127.0.0.1:9999", 1)
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+
+	b.RecordFailure(2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	b.RecordFailure(2, time.Nanosecond)
+
+	if !b.Healthy() {
+		t.Fatalf("expected backend to stay healthy once failures fall outside fail_timeout")
+	}
+}
+
+func TestPoolStatus(t *testing.T) {
+	healthyBackend, err := NewBackend("http://127.0.0.1:9001", 1)
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	downBackend, err := NewBackend("http://127.0.0.1:9002", 1)
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	downBackend.SetHealthy(false)
+
+	cases := []struct {
+		name     string
+		backends []*Backend
+		want     string
+	}{
+		{"all healthy", []*Backend{healthyBackend}, "ok"},
+		{"some down", []*Backend{healthyBackend, downBackend}, "degraded"},
+		{"all down", []*Backend{downBackend}, "unhealthy"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := New("test", &RoundRobin{}, tc.backends...)
+			if got := p.Status(); got != tc.want {
+				t.Fatalf("Status() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package pool
+
+import (
+	"hash/fnv"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// Strategy picks a backend out of a set of candidates, which are always
+// pre-filtered to the currently healthy ones by Pool.Next.
+type Strategy interface {
+	// Next returns the chosen backend, keyed off the incoming request's
+	// remote address (used by IPHash; ignored by the others).
+	Next(backends []*Backend, remoteAddr string) *Backend
+}
+
+// RoundRobin cycles through backends in order.
+type RoundRobin struct {
+	counter atomic.Uint64
+}
+
+func (s *RoundRobin) Next(backends []*Backend, _ string) *Backend {
+	if len(backends) == 0 {
+		return nil
+	}
+	i := s.counter.Add(1) - 1
+	return backends[i%uint64(len(backends))]
+}
+
+// WeightedRoundRobin distributes requests proportionally to each backend's
+// Weight using Nginx's smooth weighted round-robin algorithm.
+type WeightedRoundRobin struct {
+	mu      sync.Mutex
+	current map[*Backend]int
+}
+
+func (s *WeightedRoundRobin) Next(backends []*Backend, _ string) *Backend {
+	if len(backends) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		s.current = make(map[*Backend]int, len(backends))
+	}
+
+	total := 0
+	var best *Backend
+	for _, b := range backends {
+		weight := b.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+
+		s.current[b] += weight
+		if best == nil || s.current[b] > s.current[best] {
+			best = b
+		}
+	}
+
+	s.current[best] -= total
+	return best
+}
+
+// LeastConnections picks the healthy backend with the fewest in-flight
+// requests, breaking ties by order.
+type LeastConnections struct{}
+
+func (s *LeastConnections) Next(backends []*Backend, _ string) *Backend {
+	if len(backends) == 0 {
+		return nil
+	}
+
+	best := backends[0]
+	for _, b := range backends[1:] {
+		if b.InFlight() < best.InFlight() {
+			best = b
+		}
+	}
+	return best
+}
+
+// IPHash consistently maps a client's remote address onto the same backend,
+// so long as the set of healthy backends doesn't change.
+type IPHash struct{}
+
+func (s *IPHash) Next(backends []*Backend, remoteAddr string) *Backend {
+	if len(backends) == 0 {
+		return nil
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(clientIP(remoteAddr)))
+	return backends[h.Sum32()%uint32(len(backends))]
+}
+
+// clientIP strips the ephemeral source port from a "host:port" remote
+// address so a client's repeat connections (each from a new port) hash to
+// the same backend. remoteAddr is returned unchanged if it has no port.
+func clientIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
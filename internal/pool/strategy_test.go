@@ -0,0 +1,73 @@
+package pool
+
+import "testing"
+
+func namedBackend(t *testing.T, name string, weight int) *Backend {
+	t.Helper()
+	b, err := NewBackend("http://"+name, weight)
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	return b
+}
+
+func TestWeightedRoundRobinSmoothSequence(t *testing.T) {
+	a := namedBackend(t, "a", 5)
+	b := namedBackend(t, "b", 1)
+	c := namedBackend(t, "c", 1)
+	backends := []*Backend{a, b, c}
+
+	s := &WeightedRoundRobin{}
+
+	want := []*Backend{a, a, b, a, c, a, a}
+	for i, wantBackend := range want {
+		got := s.Next(backends, "")
+		if got != wantBackend {
+			t.Fatalf("pick %d: got %s, want %s", i, got.URL, wantBackend.URL)
+		}
+	}
+}
+
+func TestLeastConnectionsBreaksTiesByOrder(t *testing.T) {
+	a := namedBackend(t, "a", 1)
+	b := namedBackend(t, "b", 1)
+	backends := []*Backend{a, b}
+
+	s := &LeastConnections{}
+
+	if got := s.Next(backends, ""); got != a {
+		t.Fatalf("expected tie to break to the first backend, got %s", got.URL)
+	}
+
+	done := a.StartRequest()
+	t.Cleanup(done)
+
+	if got := s.Next(backends, ""); got != b {
+		t.Fatalf("expected the backend with fewer in-flight requests, got %s", got.URL)
+	}
+}
+
+func TestIPHashIsStableAcrossRepeatConnections(t *testing.T) {
+	backends := []*Backend{
+		namedBackend(t, "a", 1),
+		namedBackend(t, "b", 1),
+		namedBackend(t, "c", 1),
+	}
+
+	s := &IPHash{}
+
+	first := s.Next(backends, "203.0.113.10:54321")
+	for _, port := range []string{"203.0.113.10:1", "203.0.113.10:65000", "203.0.113.10:443"} {
+		if got := s.Next(backends, port); got != first {
+			t.Fatalf("remote addr %q: got %s, want the same backend as the first connection (%s)", port, got.URL, first.URL)
+		}
+	}
+
+	other := s.Next(backends, "198.51.100.7:54321")
+	if other == first {
+		// Not strictly guaranteed for every possible pair, but true for
+		// this fixed set of addresses and backends, and worth catching if
+		// the hash ever collapses to "always pick the same backend".
+		t.Fatalf("expected a different client IP to be able to land on a different backend")
+	}
+}
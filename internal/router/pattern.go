@@ -0,0 +1,102 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// matcher reports whether a request path matches a compiled Rule.Path,
+// returning any path parameters it captured.
+type matcher interface {
+	match(path string) (vars map[string]string, ok bool)
+}
+
+func newMatcher(path string, t MatchType) (matcher, error) {
+	switch t {
+	case MatchPrefix:
+		return prefixMatcher(path), nil
+	case MatchExact:
+		return newParamMatcher(path)
+	case MatchRegex:
+		return newRegexMatcher(path)
+	default:
+		return nil, fmt.Errorf("unknown match type %d", t)
+	}
+}
+
+type prefixMatcher string
+
+func (p prefixMatcher) match(path string) (map[string]string, bool) {
+	return nil, strings.HasPrefix(path, string(p))
+}
+
+// paramPattern matches "{name}" path-parameter placeholders.
+var paramPattern = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+// paramMatcher compiles an exact-match path pattern like "/users/{id}"
+// into a regexp with a named capture group per {name} placeholder.
+type paramMatcher struct {
+	re *regexp.Regexp
+}
+
+func newParamMatcher(path string) (matcher, error) {
+	escaped := regexp.QuoteMeta(path)
+
+	// QuoteMeta escapes the braces around our placeholders too; undo that
+	// so paramPattern can still find them.
+	escaped = strings.NewReplacer(`\{`, "{", `\}`, "}").Replace(escaped)
+
+	pattern := paramPattern.ReplaceAllString(escaped, `(?P<$1>[^/]+)`)
+
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return nil, fmt.Errorf("compile path pattern %q: %w", path, err)
+	}
+
+	return paramMatcher{re: re}, nil
+}
+
+func (p paramMatcher) match(path string) (map[string]string, bool) {
+	return namedMatches(p.re, path)
+}
+
+// regexMatcher matches a caller-supplied regexp directly; any named
+// capture groups become path parameters.
+type regexMatcher struct {
+	re *regexp.Regexp
+}
+
+func newRegexMatcher(pattern string) (matcher, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile regex %q: %w", pattern, err)
+	}
+	return regexMatcher{re: re}, nil
+}
+
+func (r regexMatcher) match(path string) (map[string]string, bool) {
+	return namedMatches(r.re, path)
+}
+
+func namedMatches(re *regexp.Regexp, path string) (map[string]string, bool) {
+	m := re.FindStringSubmatch(path)
+	if m == nil {
+		return nil, false
+	}
+
+	names := re.SubexpNames()
+	if len(names) <= 1 {
+		return nil, true
+	}
+
+	vars := make(map[string]string, len(names)-1)
+	for i, name := range names {
+		if i == 0 || name == "" {
+			continue
+		}
+		vars[name] = m[i]
+	}
+
+	return vars, true
+}
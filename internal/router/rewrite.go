@@ -0,0 +1,13 @@
+package router
+
+// Rewrite describes how to transform the request path once a Rule has
+// matched, before the request is proxied. Only one of StripPrefix or
+// ReplacePath takes effect (ReplacePath wins if both are set); AddPrefix
+// always applies afterwards.
+type Rewrite struct {
+	StripPrefix string
+	AddPrefix   string
+	// ReplacePath may reference captured path parameters, e.g.
+	// "/v2/users/{id}".
+	ReplacePath string
+}
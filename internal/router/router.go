@@ -0,0 +1,152 @@
+// Package router adds path-and-method routing on top of goad-balancer's
+// host routing: within a single host, an ordered list of Rules can send
+// different methods, paths, and headers to different upstream pools.
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
+)
+
+// MatchType selects how Rule.Path is interpreted.
+type MatchType int
+
+const (
+	// MatchPrefix matches when the request path starts with Path.
+	MatchPrefix MatchType = iota
+	// MatchExact matches the full request path, optionally containing
+	// {name} path parameters (e.g. "/users/{id}").
+	MatchExact
+	// MatchRegex treats Path as a Go regexp, matched against the full
+	// request path; named capture groups become path parameters.
+	MatchRegex
+)
+
+// Rule is one routing decision within a host: if Method, Path (per Type),
+// and Headers all match, the request goes to Pool (after Rewrite, if any).
+type Rule struct {
+	// Method is matched case-sensitively against r.Method; empty matches
+	// any method.
+	Method string
+	Path   string
+	Type   MatchType
+	// Headers must all be present on the request with the exact value
+	// given; empty matches any request.
+	Headers map[string]string
+	Pool    *pool.Pool
+	Rewrite *Rewrite
+}
+
+// Router holds one host's ordered Rules plus the default pool used when
+// none of them match (preserving pre-router, host-only routing).
+type Router struct {
+	rules       []compiledRule
+	defaultPool *pool.Pool
+}
+
+type compiledRule struct {
+	rule    Rule
+	matcher matcher
+}
+
+// New compiles rules in order and returns a Router that falls back to
+// defaultPool for any request none of them match.
+func New(rules []Rule, defaultPool *pool.Pool) (*Router, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+
+	for i, r := range rules {
+		m, err := newMatcher(r.Path, r.Type)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		compiled = append(compiled, compiledRule{rule: r, matcher: m})
+	}
+
+	return &Router{rules: compiled, defaultPool: defaultPool}, nil
+}
+
+type varsKey struct{}
+
+// Vars returns the path parameters captured for the matched rule, or nil
+// if the request matched a rule with none (or the default rule).
+func Vars(r *http.Request) map[string]string {
+	vars, _ := r.Context().Value(varsKey{}).(map[string]string)
+	return vars
+}
+
+// Match finds the first rule whose Method, Path, and Headers all match r,
+// and returns its Pool along with a request carrying any captured path
+// parameters and with Rewrite applied. If no rule matches, it falls back
+// to the Router's default pool and the request unchanged.
+func (rt *Router) Match(r *http.Request) (*pool.Pool, *http.Request) {
+	for _, cr := range rt.rules {
+		if cr.rule.Method != "" && cr.rule.Method != r.Method {
+			continue
+		}
+		if !headersMatch(cr.rule.Headers, r.Header) {
+			continue
+		}
+
+		vars, ok := cr.matcher.match(r.URL.Path)
+		if !ok {
+			continue
+		}
+
+		req := r
+		if len(vars) > 0 {
+			req = r.WithContext(context.WithValue(r.Context(), varsKey{}, vars))
+		}
+		req = applyRewrite(req, cr.rule.Rewrite, vars)
+
+		return cr.rule.Pool, req
+	}
+
+	return rt.defaultPool, r
+}
+
+func headersMatch(want map[string]string, got http.Header) bool {
+	for k, v := range want {
+		if got.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRewrite rewrites req.URL.Path per rw, substituting any {name}
+// placeholders in rw.ReplacePath with captured vars.
+func applyRewrite(req *http.Request, rw *Rewrite, vars map[string]string) *http.Request {
+	if rw == nil {
+		return req
+	}
+
+	path := req.URL.Path
+
+	switch {
+	case rw.ReplacePath != "":
+		path = substituteVars(rw.ReplacePath, vars)
+	case rw.StripPrefix != "":
+		path = strings.TrimPrefix(path, rw.StripPrefix)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+
+	if rw.AddPrefix != "" {
+		path = rw.AddPrefix + path
+	}
+
+	req.URL.Path = path
+	return req
+}
+
+func substituteVars(pattern string, vars map[string]string) string {
+	for name, value := range vars {
+		pattern = strings.ReplaceAll(pattern, "{"+name+"}", value)
+	}
+	return pattern
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/pool"
+)
+
+func testPool(t *testing.T, name string) *pool.Pool {
+	t.Helper()
+	backend, err := pool.NewBackend("http://127.0.0.1:9000", 1)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	return pool.New(name, &pool.RoundRobin{}, backend)
+}
+
+func TestRouterMatchOrderingAndFallback(t *testing.T) {
+	usersPool := testPool(t, "users")
+	apiPool := testPool(t, "api")
+	defaultPool := testPool(t, "default")
+
+	rt, err := New([]Rule{
+		{Method: http.MethodGet, Path: "/users/{id}", Type: MatchExact, Pool: usersPool},
+		{Path: "/api/", Type: MatchPrefix, Pool: apiPool},
+	}, defaultPool)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		wantPool   *pool.Pool
+		wantVarID  string
+		expectVars bool
+	}{
+		{name: "exact with param", method: http.MethodGet, path: "/users/42", wantPool: usersPool, wantVarID: "42", expectVars: true},
+		{name: "method mismatch falls through to default", method: http.MethodPost, path: "/users/42", wantPool: defaultPool},
+		{name: "prefix match", method: http.MethodGet, path: "/api/widgets", wantPool: apiPool},
+		{name: "no match falls back to default", method: http.MethodGet, path: "/other", wantPool: defaultPool},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+
+			p, matchedReq := rt.Match(req)
+			if p != tc.wantPool {
+				t.Fatalf("unexpected pool: got %v want %v", p.Name, tc.wantPool.Name)
+			}
+
+			if tc.expectVars {
+				vars := Vars(matchedReq)
+				if vars["id"] != tc.wantVarID {
+					t.Fatalf("unexpected id var: got %q want %q", vars["id"], tc.wantVarID)
+				}
+			}
+		})
+	}
+}
+
+func TestRouterRegexPath(t *testing.T) {
+	ordersPool := testPool(t, "orders")
+	defaultPool := testPool(t, "default")
+
+	rt, err := New([]Rule{
+		{Path: `^/orders/(?P<year>\d{4})/(?P<id>\d+)$`, Type: MatchRegex, Pool: ordersPool},
+	}, defaultPool)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	p, req := rt.Match(httptest.NewRequest(http.MethodGet, "/orders/2024/555", nil))
+	if p != ordersPool {
+		t.Fatalf("expected orders pool, got %v", p.Name)
+	}
+
+	vars := Vars(req)
+	if vars["year"] != "2024" || vars["id"] != "555" {
+		t.Fatalf("unexpected vars: %+v", vars)
+	}
+
+	p, _ = rt.Match(httptest.NewRequest(http.MethodGet, "/orders/bad", nil))
+	if p != defaultPool {
+		t.Fatalf("expected fallback to default pool for non-matching path, got %v", p.Name)
+	}
+}
+
+func TestRouterHeaderPredicate(t *testing.T) {
+	betaPool := testPool(t, "beta")
+	defaultPool := testPool(t, "default")
+
+	rt, err := New([]Rule{
+		{Path: "/", Type: MatchPrefix, Headers: map[string]string{"X-Beta": "true"}, Pool: betaPool},
+	}, defaultPool)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	betaReq := httptest.NewRequest(http.MethodGet, "/anything", nil)
+	betaReq.Header.Set("X-Beta", "true")
+	if p, _ := rt.Match(betaReq); p != betaPool {
+		t.Fatalf("expected beta pool when X-Beta header set, got %v", p.Name)
+	}
+
+	plainReq := httptest.NewRequest(http.MethodGet, "/anything", nil)
+	if p, _ := rt.Match(plainReq); p != defaultPool {
+		t.Fatalf("expected default pool without X-Beta header, got %v", p.Name)
+	}
+}
+
+func TestApplyRewrite(t *testing.T) {
+	target := testPool(t, "target")
+	defaultPool := testPool(t, "default")
+
+	rt, err := New([]Rule{
+		{
+			Path: "/api/{rest}", Type: MatchExact, Pool: target,
+			Rewrite: &Rewrite{ReplacePath: "/{rest}"},
+		},
+	}, defaultPool)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	_, req := rt.Match(httptest.NewRequest(http.MethodGet, "/api/widgets", nil))
+	if req.URL.Path != "/widgets" {
+		t.Fatalf("unexpected rewritten path: %q", req.URL.Path)
+	}
+}
@@ -0,0 +1,109 @@
+// Package tlsconfig builds the balancer's tls.Config: SNI-based
+// certificate selection across static cert/key pairs and, optionally,
+// ACME (Let's Encrypt) for any host not covered statically.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/leo-jp-edwards/goad-balancer/internal/hostmatch"
+)
+
+// CertPair is a static certificate/key file pair for one SNI host.
+type CertPair struct {
+	CertFile string
+	KeyFile  string
+}
+
+// ACMEConfig drives an autocert.Manager for hosts without a static cert.
+type ACMEConfig struct {
+	Email        string
+	CacheDir     string
+	AllowedHosts []string
+}
+
+// Manager answers tls.Config.GetCertificate by SNI hostname.
+type Manager struct {
+	mu        sync.RWMutex
+	certs     map[string]*tls.Certificate
+	autocert  *autocert.Manager
+	strictSNI bool
+}
+
+// NewManager loads every static cert pair and, if acme is non-nil,
+// prepares an autocert.Manager for any host not covered by one. When
+// strictSNI is true, GetCertificate rejects ClientHellos whose SNI
+// matches no known host (and autocert is disabled or doesn't cover it)
+// with "tls: unrecognized name", rather than falling through to the
+// server's default certificate.
+func NewManager(staticCerts map[string]CertPair, acme *ACMEConfig, strictSNI bool) (*Manager, error) {
+	m := &Manager{
+		certs:     make(map[string]*tls.Certificate, len(staticCerts)),
+		strictSNI: strictSNI,
+	}
+
+	for host, pair := range staticCerts {
+		cert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load certificate for %q: %w", host, err)
+		}
+		m.certs[hostmatch.Canonical(host)] = &cert
+	}
+
+	if acme != nil {
+		m.autocert = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(acme.CacheDir),
+			HostPolicy: autocert.HostWhitelist(acme.AllowedHosts...),
+			Email:      acme.Email,
+		}
+	}
+
+	return m, nil
+}
+
+// GetCertificate is suitable for tls.Config.GetCertificate: it looks up
+// hello.ServerName against the configured static certs (canonicalized the
+// same way as HTTP host routing), falls through to ACME when configured
+// (in strict-SNI mode, only for hosts its allowed-hosts policy accepts),
+// and otherwise either lets the caller supply a default certificate or,
+// in strict-SNI mode, rejects the handshake outright.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := hostmatch.Canonical(hello.ServerName)
+
+	m.mu.RLock()
+	cert, ok := m.certs[host]
+	m.mu.RUnlock()
+	if ok {
+		return cert, nil
+	}
+
+	if m.autocert != nil {
+		if !m.strictSNI || m.autocert.HostPolicy(context.Background(), host) == nil {
+			return m.autocert.GetCertificate(hello)
+		}
+	}
+
+	if m.strictSNI {
+		return nil, errors.New("tls: unrecognized name")
+	}
+
+	return nil, nil
+}
+
+// ACMEHandler returns the ACME HTTP-01 challenge handler wrapped around
+// fallback, or fallback unchanged if ACME isn't configured. Callers should
+// mount the result on the plain HTTP listener ahead of any HTTPS redirect.
+func (m *Manager) ACMEHandler(fallback http.Handler) http.Handler {
+	if m.autocert == nil {
+		return fallback
+	}
+	return m.autocert.HTTPHandler(fallback)
+}
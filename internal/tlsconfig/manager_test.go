@@ -0,0 +1,165 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// selfSignedCert writes a throwaway cert/key pair for a host and returns
+// the file paths. The handshake tests below sign real TLS handshakes with
+// the generated key; the others exercise only loading and lookup.
+func selfSignedCert(t *testing.T, host string) (certFile, keyFile string) {
+	t.Helper()
+
+	certPEM, keyPEM := generateSelfSigned(t, host)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestManagerGetCertificateMatchesBySNI(t *testing.T) {
+	certFile, keyFile := selfSignedCert(t, "mango.com")
+
+	m, err := NewManager(map[string]CertPair{
+		"Mango.com": {CertFile: certFile, KeyFile: keyFile},
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "mango.com:443"})
+	if err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	if cert == nil {
+		t.Fatalf("expected a certificate for mango.com")
+	}
+}
+
+func TestManagerGetCertificateUnknownHost(t *testing.T) {
+	certFile, keyFile := selfSignedCert(t, "mango.com")
+
+	m, err := NewManager(map[string]CertPair{
+		"mango.com": {CertFile: certFile, KeyFile: keyFile},
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "apple.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	if cert != nil {
+		t.Fatalf("expected no certificate for an unknown host")
+	}
+}
+
+// newTLSTestServer starts an httptest server whose tls.Config.GetCertificate
+// is m's, so a real TLS handshake exercises SNI selection end to end rather
+// than calling GetCertificate directly.
+func newTLSTestServer(t *testing.T, m *Manager) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	}))
+	ts.TLS = &tls.Config{GetCertificate: m.GetCertificate}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestManagerHandshakeSelectsCertBySNI(t *testing.T) {
+	mangoCert, mangoKey := selfSignedCert(t, "mango.com")
+	appleCert, appleKey := selfSignedCert(t, "apple.com")
+
+	m, err := NewManager(map[string]CertPair{
+		"mango.com": {CertFile: mangoCert, KeyFile: mangoKey},
+		"apple.com": {CertFile: appleCert, KeyFile: appleKey},
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	ts := newTLSTestServer(t, m)
+	addr := strings.TrimPrefix(ts.URL, "https://")
+
+	cases := []struct {
+		sni    string
+		wantCN string
+	}{
+		{sni: "mango.com", wantCN: "mango.com"},
+		{sni: "apple.com", wantCN: "apple.com"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.sni, func(t *testing.T) {
+			conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: tc.sni, InsecureSkipVerify: true})
+			if err != nil {
+				t.Fatalf("tls.Dial: %v", err)
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+
+			certs := conn.ConnectionState().PeerCertificates
+			if len(certs) == 0 {
+				t.Fatalf("no peer certificates presented")
+			}
+			if got := certs[0].Subject.CommonName; got != tc.wantCN {
+				t.Fatalf("unexpected certificate: got CN %q want %q", got, tc.wantCN)
+			}
+		})
+	}
+}
+
+func TestManagerHandshakeStrictSNIRejectsUnknownHost(t *testing.T) {
+	certFile, keyFile := selfSignedCert(t, "mango.com")
+
+	m, err := NewManager(map[string]CertPair{
+		"mango.com": {CertFile: certFile, KeyFile: keyFile},
+	}, nil, true)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	ts := newTLSTestServer(t, m)
+	addr := strings.TrimPrefix(ts.URL, "https://")
+
+	_, err = tls.Dial("tcp", addr, &tls.Config{ServerName: "apple.com", InsecureSkipVerify: true})
+	if err == nil {
+		t.Fatalf("expected the handshake to fail for an unknown SNI in strict mode")
+	}
+}
+
+func TestManagerStrictSNIRejectsUnknownHost(t *testing.T) {
+	certFile, keyFile := selfSignedCert(t, "mango.com")
+
+	m, err := NewManager(map[string]CertPair{
+		"mango.com": {CertFile: certFile, KeyFile: keyFile},
+	}, nil, true)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	_, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: "apple.com"})
+	if err == nil || err.Error() != "tls: unrecognized name" {
+		t.Fatalf("expected unrecognized name error, got %v", err)
+	}
+}